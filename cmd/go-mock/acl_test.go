@@ -0,0 +1,75 @@
+package gomock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newACLTestServer(t *testing.T) *Server {
+	t.Helper()
+	server := newRelationsTestServer(t)
+	server.authEnabled = true
+	server.jwtSecret = []byte("test-secret")
+	server.acl = ACLConfig{"comments": ACLRule{Roles: []string{"admin"}}}
+
+	if err := server.storage.Put("posts", 1, map[string]interface{}{"id": 1, "title": "hello"}); err != nil {
+		t.Fatalf("Put posts: %v", err)
+	}
+	if err := server.storage.Put("comments", 1, map[string]interface{}{"id": 1, "postId": 1, "body": "secret"}); err != nil {
+		t.Fatalf("Put comments: %v", err)
+	}
+	return server
+}
+
+// TestSubResourceEnforcesOwnACL guards against an ACL rule on a nested
+// collection being bypassed by reaching it through an unprotected parent's
+// sub-resource route: GET /posts/1/comments must be rejected the same way
+// GET /comments would be.
+func TestSubResourceEnforcesOwnACL(t *testing.T) {
+	server := newACLTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/1/comments", nil)
+	rec := httptest.NewRecorder()
+	server.handleCollection(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
+
+// TestEmbedEnforcesTargetACL guards against an ACL rule on an embedded
+// collection being bypassed via ?_embed= on an unprotected parent
+// collection.
+func TestEmbedEnforcesTargetACL(t *testing.T) {
+	server := newACLTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/1?_embed=comments", nil)
+	rec := httptest.NewRecorder()
+	server.handleCollection(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
+
+// TestExpandEnforcesTargetACL mirrors TestEmbedEnforcesTargetACL for
+// "_expand".
+func TestExpandEnforcesTargetACL(t *testing.T) {
+	server := newACLTestServer(t)
+	server.acl = ACLConfig{"users": ACLRule{Roles: []string{"admin"}}}
+	if err := server.storage.Put("posts", 1, map[string]interface{}{"id": 1, "userId": 1, "title": "hello"}); err != nil {
+		t.Fatalf("Put posts: %v", err)
+	}
+	if err := server.storage.Put("users", 1, map[string]interface{}{"id": 1, "name": "ada"}); err != nil {
+		t.Fatalf("Put users: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/1?_expand=user", nil)
+	rec := httptest.NewRecorder()
+	server.handleCollection(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
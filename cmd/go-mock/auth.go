@@ -0,0 +1,277 @@
+package gomock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// authContextKey is the context key under which the authenticated
+// request's JWT claims are stored, once s.authorize has run.
+type authContextKey string
+
+const claimsContextKey authContextKey = "claims"
+
+// authClaims are the JWT claims issued by POST /auth/login and checked by
+// the auth middleware in handleCollection.
+type authClaims struct {
+	jwt.RegisteredClaims
+	Role string `json:"role"`
+}
+
+// ACLRule describes the access requirements for a single collection: the
+// roles allowed to access it at all, and whether PUT/DELETE are further
+// restricted to the item's own owner (via its "userId" field).
+type ACLRule struct {
+	Roles     []string `json:"roles,omitempty"`
+	OwnerOnly bool     `json:"ownerOnly,omitempty"`
+}
+
+// ACLConfig maps collection name to its ACLRule. Collections absent from
+// the config are unprotected even when -auth is enabled.
+type ACLConfig map[string]ACLRule
+
+// loadACLConfig reads the ACL file configured via -acl.
+//
+// Parameters:
+//   - filename: Path to the JSON ACL file
+//
+// Returns:
+//   - ACLConfig: Parsed per-collection access rules
+//   - error: Any error encountered while reading or parsing the file
+func loadACLConfig(filename string) (ACLConfig, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var acl ACLConfig
+	if err := json.Unmarshal(raw, &acl); err != nil {
+		return nil, err
+	}
+	return acl, nil
+}
+
+// handleAuthRegister handles POST /auth/register: it hashes the given
+// password with bcrypt and stores the new user in the "users" collection,
+// returning the created user without its password fields.
+//
+// Returns:
+//   - int: HTTP response status code
+func (s *Server) handleAuthRegister(w http.ResponseWriter, r *http.Request) int {
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return http.StatusBadRequest
+	}
+
+	password, _ := body["password"].(string)
+	if password == "" {
+		http.Error(w, "password is required", http.StatusBadRequest)
+		return http.StatusBadRequest
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		return http.StatusInternalServerError
+	}
+	delete(body, "password")
+	body["passwordHash"] = string(hash)
+
+	items, _ := s.storage.List("users")
+	id := len(items) + 1
+	body["id"] = id
+
+	if err := s.storage.Put("users", id, body); err != nil {
+		http.Error(w, "Failed to save data", http.StatusInternalServerError)
+		return http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sanitizeUser(body))
+	return http.StatusCreated
+}
+
+// handleAuthLogin handles POST /auth/login: it verifies the given username
+// and password against the "users" collection and, on success, issues a
+// signed JWT carrying the user's id (as the subject) and role.
+//
+// Returns:
+//   - int: HTTP response status code
+func (s *Server) handleAuthLogin(w http.ResponseWriter, r *http.Request) int {
+	var credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return http.StatusBadRequest
+	}
+
+	users, _ := s.storage.List("users")
+	for _, u := range users {
+		user, ok := u.(map[string]interface{})
+		if !ok || fmt.Sprint(user["username"]) != credentials.Username {
+			continue
+		}
+
+		hash, _ := user["passwordHash"].(string)
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(credentials.Password)) != nil {
+			break
+		}
+
+		role, _ := user["role"].(string)
+		if role == "" {
+			role = "user"
+		}
+
+		token, err := issueToken(s.jwtSecret, fmt.Sprint(user["id"]), role)
+		if err != nil {
+			http.Error(w, "Failed to issue token", http.StatusInternalServerError)
+			return http.StatusInternalServerError
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"token": token})
+		return http.StatusOK
+	}
+
+	http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+	return http.StatusUnauthorized
+}
+
+// sanitizeUser returns a copy of user with password fields stripped, safe
+// to send back in a response.
+func sanitizeUser(user map[string]interface{}) map[string]interface{} {
+	clean := make(map[string]interface{}, len(user))
+	for k, v := range user {
+		if k == "password" || k == "passwordHash" {
+			continue
+		}
+		clean[k] = v
+	}
+	return clean
+}
+
+// issueToken signs a new HS256 JWT for the given user id and role, valid
+// for 24 hours.
+func issueToken(secret []byte, userID, role string) (string, error) {
+	now := time.Now()
+	claims := authClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(24 * time.Hour)),
+		},
+		Role: role,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+}
+
+// parseToken verifies and decodes a bearer token string using secret.
+func parseToken(secret []byte, tokenString string) (*authClaims, error) {
+	claims := &authClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return secret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>"
+// request header, returning "" if absent or malformed.
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(header, prefix)
+}
+
+// authorize enforces the ACL rule configured for collection, when -auth is
+// enabled and a rule exists for it. On success it returns a request carrying
+// the caller's JWT claims in its context, for owner-only checks further
+// down the handler chain.
+//
+// Returns:
+//   - *http.Request: The request, possibly carrying auth claims in its context
+//   - bool: Whether the request is authorized to proceed
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, collection string) (*http.Request, bool) {
+	rule, protected := s.acl[collection]
+	if !s.authEnabled || !protected {
+		return r, true
+	}
+
+	token := bearerToken(r)
+	if token == "" {
+		http.Error(w, "Missing bearer token", http.StatusUnauthorized)
+		return r, false
+	}
+
+	claims, err := parseToken(s.jwtSecret, token)
+	if err != nil {
+		http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+		return r, false
+	}
+
+	if len(rule.Roles) > 0 && !hasRole(rule.Roles, claims.Role) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return r, false
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), claimsContextKey, claims)), true
+}
+
+// hasRole reports whether role appears in roles.
+func hasRole(roles []string, role string) bool {
+	for _, candidate := range roles {
+		if candidate == role {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizeOwner enforces ACLRule.OwnerOnly for collection: when set, the
+// authenticated caller's subject must match item's "userId" field.
+//
+// Returns:
+//   - bool: true if the request is allowed to modify item
+func (s *Server) authorizeOwner(w http.ResponseWriter, r *http.Request, collection string, item map[string]interface{}) bool {
+	rule, protected := s.acl[collection]
+	if !s.authEnabled || !protected || !rule.OwnerOnly {
+		return true
+	}
+
+	claims, _ := r.Context().Value(claimsContextKey).(*authClaims)
+	if claims == nil || fmt.Sprint(item["userId"]) != claims.Subject {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// resolveJWTSecret returns the configured JWT signing secret: the -jwt-secret
+// flag value if set, otherwise the GOMOCK_JWT_SECRET environment variable.
+func resolveJWTSecret(flagValue, envValue string) []byte {
+	if flagValue != "" {
+		return []byte(flagValue)
+	}
+	return []byte(envValue)
+}
+
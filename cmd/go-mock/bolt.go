@@ -0,0 +1,115 @@
+package gomock
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltStorage persists collections in an embedded BoltDB file, one bucket
+// per collection keyed by each item's id, zero-padded (see boltKey) so
+// List's bucket scan returns items in numeric id order. Unlike
+// FileStorage, a Put or Delete only touches the affected key, making it
+// suitable for mock databases under high write volume.
+type BoltStorage struct {
+	db *bolt.DB
+}
+
+// boltKey encodes id as a fixed-width, zero-padded decimal string so that
+// bucket.ForEach's lexicographic byte order (used by List) agrees with
+// numeric id order, matching the insertion-order guarantee every other
+// Storage backend gives its callers.
+func boltKey(id int) []byte {
+	return []byte(fmt.Sprintf("%020d", id))
+}
+
+// NewBoltStorage opens (creating if necessary) a BoltDB file at filename.
+func NewBoltStorage(filename string) (*BoltStorage, error) {
+	db, err := bolt.Open(filename, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &BoltStorage{db: db}, nil
+}
+
+// Load is a no-op: BoltStorage reads directly from the database file on
+// every List/Get rather than caching collections in memory.
+func (bs *BoltStorage) Load() error { return nil }
+
+// Save is a no-op: Put and Delete persist immediately.
+func (bs *BoltStorage) Save() error { return nil }
+
+// List returns every item in the collection's bucket, and whether the
+// bucket exists.
+func (bs *BoltStorage) List(collection string) ([]interface{}, bool) {
+	var items []interface{}
+	exists := false
+
+	bs.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(collection))
+		if bucket == nil {
+			return nil
+		}
+		exists = true
+		return bucket.ForEach(func(_, value []byte) error {
+			var item map[string]interface{}
+			if err := json.Unmarshal(value, &item); err != nil {
+				return err
+			}
+			items = append(items, item)
+			return nil
+		})
+	})
+
+	return items, exists
+}
+
+// Get looks up a single item by id within the collection's bucket.
+func (bs *BoltStorage) Get(collection string, id int) (map[string]interface{}, bool) {
+	var item map[string]interface{}
+	found := false
+
+	bs.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(collection))
+		if bucket == nil {
+			return nil
+		}
+		value := bucket.Get(boltKey(id))
+		if value == nil {
+			return nil
+		}
+		found = json.Unmarshal(value, &item) == nil
+		return nil
+	})
+
+	return item, found
+}
+
+// Put marshals item and stores it under id in the collection's bucket,
+// creating the bucket on first write.
+func (bs *BoltStorage) Put(collection string, id int, item map[string]interface{}) error {
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(collection))
+		if err != nil {
+			return err
+		}
+		return bucket.Put(boltKey(id), raw)
+	})
+}
+
+// Delete removes id's key from the collection's bucket, if present.
+func (bs *BoltStorage) Delete(collection string, id int) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(collection))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete(boltKey(id))
+	})
+}
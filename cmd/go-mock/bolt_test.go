@@ -0,0 +1,46 @@
+package gomock
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// TestBoltStorageListOrdersByNumericID guards against List returning items
+// in lexicographic key order (1, 10, 11, 12, 2, 3, ...) instead of numeric
+// insertion order, which every other Storage backend preserves.
+func TestBoltStorageListOrdersByNumericID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	storage, err := NewBoltStorage(path)
+	if err != nil {
+		t.Fatalf("NewBoltStorage: %v", err)
+	}
+
+	ids := []int{1, 2, 3, 9, 10, 11, 12}
+	for _, id := range ids {
+		item := map[string]interface{}{"id": float64(id)}
+		if err := storage.Put("widgets", id, item); err != nil {
+			t.Fatalf("Put %d: %v", id, err)
+		}
+	}
+
+	items, exists := storage.List("widgets")
+	if !exists {
+		t.Fatalf("expected \"widgets\" to exist")
+	}
+	if len(items) != len(ids) {
+		t.Fatalf("expected %d items, got %d", len(ids), len(items))
+	}
+
+	for i, item := range items {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			t.Fatalf("item %d: expected map[string]interface{}, got %T", i, item)
+		}
+		got := fmt.Sprint(itemMap["id"])
+		want := fmt.Sprint(float64(ids[i]))
+		if got != want {
+			t.Fatalf("item %d: expected id %s, got %s", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package gomock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// changeEvent describes a single mutation broadcast to /_changes
+// subscribers.
+type changeEvent struct {
+	Op         string      `json:"op"`
+	Collection string      `json:"collection"`
+	ID         int         `json:"id"`
+	Item       interface{} `json:"item,omitempty"`
+	Timestamp  time.Time   `json:"ts"`
+}
+
+// changeSubscriber receives change events over ch, optionally filtered to
+// a single collection ("" means every collection).
+type changeSubscriber struct {
+	ch         chan changeEvent
+	collection string
+}
+
+// changeHub tracks every active /_changes subscriber and fans out
+// broadcast events to them.
+type changeHub struct {
+	mu          sync.Mutex
+	subscribers map[*changeSubscriber]struct{}
+}
+
+func newChangeHub() *changeHub {
+	return &changeHub{subscribers: make(map[*changeSubscriber]struct{})}
+}
+
+// subscribe registers a new subscriber filtered to collection and returns
+// it for the caller to read from and later pass to unsubscribe.
+func (h *changeHub) subscribe(collection string) *changeSubscriber {
+	sub := &changeSubscriber{ch: make(chan changeEvent, 16), collection: collection}
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// unsubscribe removes sub and closes its channel.
+func (h *changeHub) unsubscribe(sub *changeSubscriber) {
+	h.mu.Lock()
+	delete(h.subscribers, sub)
+	h.mu.Unlock()
+	close(sub.ch)
+}
+
+// broadcast fans event out to every subscriber whose filter matches,
+// dropping it for any subscriber that isn't keeping up rather than
+// blocking the mutating request that triggered it.
+func (h *changeHub) broadcast(event changeEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		if sub.collection != "" && sub.collection != event.Collection {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+// wsUpgrader upgrades /_changes requests that ask for a WebSocket. Origin
+// checking is left to whatever sits in front of the mock server, matching
+// its role as a local development tool rather than a public service.
+var wsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// handleChanges serves GET /_changes, streaming every collection mutation
+// as a JSON event. It upgrades to a WebSocket when the request carries the
+// appropriate Upgrade header, and otherwise falls back to Server-Sent
+// Events. An optional "?collection=" query parameter filters events to a
+// single collection.
+//
+// The caller (handleCollection) is responsible for running the subscribed
+// collection through s.authorize before calling handleChanges, the same
+// as it does for the collection's own CRUD routes.
+//
+// Parameters:
+//   - w: HTTP response writer
+//   - r: HTTP request
+func (s *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	collection := r.URL.Query().Get("collection")
+	sub := s.changes.subscribe(collection)
+	defer s.changes.unsubscribe(sub)
+
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveChangesWebSocket(w, r, sub)
+		return
+	}
+	s.serveChangesSSE(w, r, sub)
+}
+
+// serveChangesSSE streams sub's events as text/event-stream until the
+// client disconnects.
+func (s *Server) serveChangesSSE(w http.ResponseWriter, r *http.Request, sub *changeSubscriber) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case event, ok := <-sub.ch:
+			if !ok {
+				return
+			}
+			raw, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", raw)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// serveChangesWebSocket streams sub's events as JSON text frames over an
+// upgraded WebSocket connection until the client disconnects.
+func (s *Server) serveChangesWebSocket(w http.ResponseWriter, r *http.Request, sub *changeSubscriber) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	for event := range sub.ch {
+		if err := conn.WriteJSON(event); err != nil {
+			return
+		}
+	}
+}
+
+// broadcastChange publishes a mutation event for id in collection. Called
+// by handlePost/handlePut/handleDelete once the mutation has been
+// successfully persisted.
+func (s *Server) broadcastChange(op, collection string, id int, item interface{}) {
+	s.changes.broadcast(changeEvent{
+		Op:         op,
+		Collection: collection,
+		ID:         id,
+		Item:       item,
+		Timestamp:  time.Now(),
+	})
+}
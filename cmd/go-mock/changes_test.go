@@ -0,0 +1,47 @@
+package gomock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestChangesRequiresAuthForProtectedCollection guards against /_changes
+// bypassing the same ACL enforcement as the collection it subscribes to:
+// with auth enabled and an ACL rule on "users", an unauthenticated
+// subscription must be rejected rather than silently streaming events.
+func TestChangesRequiresAuthForProtectedCollection(t *testing.T) {
+	server := newRelationsTestServer(t)
+	server.authEnabled = true
+	server.jwtSecret = []byte("test-secret")
+	server.acl = ACLConfig{"users": ACLRule{Roles: []string{"admin"}}}
+
+	req := httptest.NewRequest(http.MethodGet, "/_changes?collection=users", nil)
+	rec := httptest.NewRecorder()
+
+	server.handleCollection(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
+
+// TestChangesRequiresCollectionWhenAuthEnabled guards against an
+// unfiltered /_changes subscription (no "collection" query parameter)
+// sidestepping ACL enforcement entirely by streaming every collection's
+// events, protected or not.
+func TestChangesRequiresCollectionWhenAuthEnabled(t *testing.T) {
+	server := newRelationsTestServer(t)
+	server.authEnabled = true
+	server.jwtSecret = []byte("test-secret")
+	server.acl = ACLConfig{"users": ACLRule{Roles: []string{"admin"}}}
+
+	req := httptest.NewRequest(http.MethodGet, "/_changes", nil)
+	rec := httptest.NewRecorder()
+
+	server.handleCollection(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
@@ -7,6 +7,9 @@
 //   - PUT: Update an existing item in a collection
 //   - DELETE: Remove an item from a collection
 //
+// GET /_changes streams every mutation as it happens, over SSE or a
+// WebSocket upgrade.
+//
 // Usage:
 //
 //	go run main.go -db=mydata.json -port=8080
@@ -17,94 +20,201 @@
 //	  	Path to the JSON database file (default "db.json")
 //	-port int
 //	  	Port number for the server to listen on (default 3000)
+//	-routes string
+//	  	Path to a JSON routes config file for custom URL patterns and canned responses
+//	-storage string
+//	  	Storage backend: "file", "dir", "memory", or "bolt" (default "file")
+//	-delay string
+//	  	Fake latency per request, fixed ("200ms") or random range ("100ms-800ms")
+//	-error-rate float
+//	  	Fraction of requests (0-1) that fail with -error-status (default 500)
+//	-rate-limit string
+//	  	Per-client-IP request limit, e.g. "100/min"
+//	-chaos string
+//	  	Path to a JSON file of per-collection chaos overrides (routes
+//	  	loaded via -routes can also carry their own "chaos" override)
+//	-auth
+//	  	Enforce JWT auth and ACLs on protected collections (default false)
+//	-jwt-secret string
+//	  	Secret used to sign JWTs (falls back to GOMOCK_JWT_SECRET)
+//	-acl string
+//	  	Path to a JSON ACL file mapping collection to required roles/ownership
+//	-schemas string
+//	  	Path to a JSON file mapping collection to a JSON Schema (draft 2020-12)
 package gomock
 
 import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // Server represents the mock JSON server with data management capabilities.
-// It maintains an in-memory collection of data and provides methods to interact with it.
+// It delegates persistence to a Storage backend and provides methods to
+// interact with the collections it holds.
 type Server struct {
 
-	// data stores collections as a map where each key is a collection name
-	// and the value is a slice of items (represented as map[string]interface{})
-	data map[string][]interface{}
-
-	// filename is the path to the JSON file used for persistent storage
-	filename string
+	// storage is the backend responsible for loading and persisting
+	// collections, selected via the -storage flag.
+	storage Storage
 
 	// logger is used for logging server activities and requests
 	logger *log.Logger
+
+	// routes holds user-defined route rules loaded via the -routes flag.
+	// A nil routes means no custom routes were configured.
+	routes *RouteConfig
+
+	// chaos is the default latency/error/rate-limit behavior, configured via
+	// -delay, -error-rate, and -rate-limit. It applies to collection requests
+	// with no per-collection or per-route override; canned route responses,
+	// /auth, and /_changes requests are not subject to it.
+	chaos ChaosConfig
+
+	// chaosOverrides holds per-collection chaos behavior loaded from the
+	// -chaos flag, taking precedence over chaos for a matching collection.
+	chaosOverrides map[string]ChaosConfig
+
+	// limiters holds the rate limiter for each collection (whether it uses
+	// its own override or the global chaos config) and for each route rule
+	// (keyed "route:" plus its path), created lazily on first use.
+	limiters   map[string]*rateLimiter
+	limitersMu sync.Mutex
+
+	// authEnabled turns on JWT auth enforcement, via the -auth flag.
+	// Existing unauthenticated use keeps working when it is false.
+	authEnabled bool
+
+	// jwtSecret signs and verifies the JWTs issued by POST /auth/login.
+	jwtSecret []byte
+
+	// acl maps collection name to its access rule, loaded via -acl.
+	acl ACLConfig
+
+	// changes fans out every mutation to /_changes subscribers.
+	changes *changeHub
+
+	// schemas holds the compiled per-collection JSON Schemas loaded via
+	// -schemas. A nil schemas means request bodies are never validated.
+	schemas *schemaRegistry
 }
 
-// NewServer initializes a new Server instance by loading data from a JSON file.
+// NewServer initializes a new Server instance backed by storage, loading
+// its existing data.
 //
 // Parameters:
-//   - filename: Path to the JSON database file
+//   - storage: Storage backend to load collections from and persist to
 //
 // Returns:
 //   - *Server: Configured server instance
-//   - error: Any error encountered during initialization
-func NewServer(filename string) (*Server, error) {
+//   - error: Any error encountered while loading data
+func NewServer(storage Storage) (*Server, error) {
 	server := &Server{
-		data:     make(map[string][]interface{}),
-		filename: filename,
+		storage:  storage,
 		logger:   log.New(os.Stdout, "GO-MOCK: ", log.Ldate|log.Ltime|log.Lshortfile),
+		limiters: make(map[string]*rateLimiter),
+		changes:  newChangeHub(),
 	}
 
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	byteValue, _ := ioutil.ReadAll(file)
-	if err := json.Unmarshal(byteValue, &server.data); err != nil {
+	if err := storage.Load(); err != nil {
 		return nil, err
 	}
 
 	return server, nil
 }
 
-// saveData writes the current in-memory data to the JSON file.
-//
-// Returns:
-//   - error: Any error encountered while saving data
-func (s *Server) saveData() error {
-	file, err := json.MarshalIndent(s.data, "", " ")
-	if err != nil {
-		return err
-	}
-	return ioutil.WriteFile(s.filename, file, 0644)
-}
-
 // Run starts the mock server with command-line configurable options.
 //
 // Flags:
-//   - db: JSON database file path (default: "db.json")
+//   - db: JSON database file path, or directory for the "dir" backend (default: "db.json")
 //   - port: Server listening port (default: 3000)
+//   - storage: Storage backend name (default: "file")
 //
 // Returns:
 //   - error: Any error encountered while starting the server
 func Run() error {
 	filename := flag.String("db", "db.json", "JSON database file")
 	port := flag.Int("port", 3000, "Server port")
+	storageKind := flag.String("storage", "file", "Storage backend: file, dir, memory, or bolt")
+	routesFile := flag.String("routes", "", "Path to a JSON routes config file")
+	delayFlag := flag.String("delay", "", `Fake latency per request, fixed ("200ms") or random range ("100ms-800ms")`)
+	errorRate := flag.Float64("error-rate", 0, "Fraction of requests (0-1) that fail with -error-status")
+	errorStatus := flag.Int("error-status", http.StatusInternalServerError, "HTTP status returned for -error-rate failures")
+	rateLimitFlag := flag.String("rate-limit", "", `Per-client-IP request limit, e.g. "100/min"`)
+	chaosFile := flag.String("chaos", "", "Path to a JSON file of per-collection chaos overrides")
+	authEnabled := flag.Bool("auth", false, "Enforce JWT auth and ACLs on protected collections")
+	jwtSecretFlag := flag.String("jwt-secret", "", "Secret used to sign JWTs (falls back to GOMOCK_JWT_SECRET)")
+	aclFile := flag.String("acl", "", "Path to a JSON ACL file mapping collection to required roles/ownership")
+	schemasFile := flag.String("schemas", "", "Path to a JSON file mapping collection to a JSON Schema (draft 2020-12)")
 	flag.Parse()
 
-	server, err := NewServer(*filename)
+	storage, err := newStorage(*storageKind, *filename)
+	if err != nil {
+		return fmt.Errorf("error configuring storage: %v", err)
+	}
+
+	server, err := NewServer(storage)
 	if err != nil {
 		return fmt.Errorf("error initializing server: %v", err)
 	}
 
+	if *routesFile != "" {
+		routes, err := loadRouteConfig(*routesFile)
+		if err != nil {
+			return fmt.Errorf("error loading routes config: %v", err)
+		}
+		server.routes = routes
+	}
+
+	delayMin, delayMax, err := parseDelayFlag(*delayFlag)
+	if err != nil {
+		return fmt.Errorf("invalid -delay: %v", err)
+	}
+	rateLimit, rateLimitWindow, err := parseRateLimitFlag(*rateLimitFlag)
+	if err != nil {
+		return fmt.Errorf("invalid -rate-limit: %v", err)
+	}
+	server.chaos = ChaosConfig{
+		DelayMin:        delayMin,
+		DelayMax:        delayMax,
+		ErrorRate:       *errorRate,
+		ErrorStatus:     *errorStatus,
+		RateLimit:       rateLimit,
+		RateLimitWindow: rateLimitWindow,
+	}
+
+	if *chaosFile != "" {
+		overrides, err := loadChaosOverrides(*chaosFile)
+		if err != nil {
+			return fmt.Errorf("error loading chaos overrides: %v", err)
+		}
+		server.chaosOverrides = overrides
+	}
+
+	server.authEnabled = *authEnabled
+	server.jwtSecret = resolveJWTSecret(*jwtSecretFlag, os.Getenv("GOMOCK_JWT_SECRET"))
+	if *aclFile != "" {
+		acl, err := loadACLConfig(*aclFile)
+		if err != nil {
+			return fmt.Errorf("error loading ACL config: %v", err)
+		}
+		server.acl = acl
+	}
+
+	if *schemasFile != "" {
+		schemas, err := loadSchemaRegistry(*schemasFile)
+		if err != nil {
+			return fmt.Errorf("error loading schemas: %v", err)
+		}
+		server.schemas = schemas
+	}
+
 	http.HandleFunc("/", server.handleCollection)
 
 	addr := fmt.Sprintf(":%d", *port)
@@ -119,9 +229,62 @@ func Run() error {
 //   - w: HTTP response writer
 //   - r: HTTP request
 func (s *Server) handleCollection(w http.ResponseWriter, r *http.Request) {
+	routeChaos := false
+	if rule, params := s.routes.match(r); rule != nil {
+		if s.applyChaosConfig(w, r, rule.chaosConfig, "route:"+rule.Path) {
+			return
+		}
+		if rule.serve(w, r, params) {
+			return
+		}
+		routeChaos = rule.Chaos != nil
+	}
+
 	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	collection := parts[0]
 
+	if collection == "_changes" && r.Method == http.MethodGet {
+		target := r.URL.Query().Get("collection")
+		if target == "" && s.authEnabled && len(s.acl) > 0 {
+			http.Error(w, "collection query parameter is required when auth is enabled", http.StatusBadRequest)
+			return
+		}
+
+		var ok bool
+		r, ok = s.authorize(w, r, target)
+		if !ok {
+			return
+		}
+		s.handleChanges(w, r)
+		return
+	}
+
+	if collection == "auth" && r.Method == http.MethodPost && len(parts) > 1 {
+		var status int
+		defer func() { s.logRequest(r, collection, status) }()
+
+		switch parts[1] {
+		case "register":
+			status = s.handleAuthRegister(w, r)
+		case "login":
+			status = s.handleAuthLogin(w, r)
+		default:
+			status = http.StatusNotFound
+			http.Error(w, "Not found", status)
+		}
+		return
+	}
+
+	if !routeChaos && s.applyChaos(w, r, collection) {
+		return
+	}
+
+	var ok bool
+	r, ok = s.authorize(w, r, collection)
+	if !ok {
+		return
+	}
+
 	var status int
 	defer func() { s.logRequest(r, collection, status) }()
 
@@ -150,27 +313,12 @@ func (s *Server) logRequest(r *http.Request, collection string, status int) {
 	s.logger.Printf("%s %s /%s - Status: %d", r.Method, r.RemoteAddr, collection, status)
 }
 
-// findItemIndex locates the index of an item in a collection by its ID.
-//
-// Parameters:
-//   - collection: Name of the collection to search
-//   - id: Numeric identifier of the item
-//
-// Returns:
-//   - int: Index of the item (or -1 if not found)
-//   - bool: Whether the item was found
-func (s *Server) findItemIndex(collection string, id int) (int, bool) {
-	items := s.data[collection]
-	for i, item := range items {
-		itemMap := item.(map[string]interface{})
-		if int(itemMap["id"].(float64)) == id {
-			return i, true
-		}
-	}
-	return -1, false
-}
-
-// handleGet processes GET requests for retrieving collection items.
+// handleGet processes GET requests for retrieving collection items. List
+// requests additionally support json-server style query parameters:
+// field-equality and operator filters (e.g. "status=active", "age_gte=18"),
+// a "q" full-text search, "_sort"/"_order" multi-field sorting, and
+// "_start"/"_end"/"_limit" pagination (reported via X-Total-Count and a
+// Link header).
 //
 // Parameters:
 //   - w: HTTP response writer
@@ -181,12 +329,14 @@ func (s *Server) findItemIndex(collection string, id int) (int, bool) {
 // Returns:
 //   - int: HTTP response status code
 func (s *Server) handleGet(w http.ResponseWriter, r *http.Request, collection string, parts []string) int {
-	items, exists := s.data[collection]
+	items, exists := s.storage.List(collection)
 	if !exists {
 		http.Error(w, "Collection not found", http.StatusNotFound)
 		return http.StatusNotFound
 	}
 
+	query := r.URL.Query()
+
 	// Handle specific item retrieval
 	if len(parts) > 1 {
 		id, err := strconv.Atoi(parts[1])
@@ -195,20 +345,108 @@ func (s *Server) handleGet(w http.ResponseWriter, r *http.Request, collection st
 			return http.StatusBadRequest
 		}
 
-		for _, item := range items {
-			itemMap := item.(map[string]interface{})
-			if int(itemMap["id"].(float64)) == id {
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(itemMap)
-				return http.StatusOK
+		// Sub-resource route, e.g. GET /posts/1/comments, resolves to the
+		// same foreign-key filter as ?postId=1 on the comments collection.
+		if len(parts) > 2 {
+			return s.handleSubResource(w, r, collection, id, parts[2])
+		}
+
+		itemMap, found := s.storage.Get(collection, id)
+		if !found {
+			http.Error(w, "Item not found", http.StatusNotFound)
+			return http.StatusNotFound
+		}
+
+		embeds := splitCSV(query.Get("_embed"))
+		expands := splitCSV(query.Get("_expand"))
+		if !s.authorizeRelations(w, r, embeds, expands) {
+			return http.StatusForbidden
+		}
+
+		itemMap = s.embedRelations(itemMap, collection, embeds)
+		itemMap = s.expandRelations(itemMap, expands)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(itemMap)
+		return http.StatusOK
+	}
+
+	result := applyFilters(items, query)
+	result = applySearch(result, query.Get("q"))
+	result = applySort(result, query.Get("_sort"), query.Get("_order"))
+
+	page := applyPagination(result, query)
+	writePaginationHeaders(w, r, page)
+
+	embeds := splitCSV(query.Get("_embed"))
+	expands := splitCSV(query.Get("_expand"))
+	if len(embeds) > 0 || len(expands) > 0 {
+		if !s.authorizeRelations(w, r, embeds, expands) {
+			return http.StatusForbidden
+		}
+		// page.items may share its backing array with the storage backend's
+		// own slice, so the resolved items go into a fresh slice.
+		resolved := make([]interface{}, len(page.items))
+		copy(resolved, page.items)
+		for i, item := range resolved {
+			itemMap, ok := item.(map[string]interface{})
+			if !ok {
+				continue
 			}
+			itemMap = s.embedRelations(itemMap, collection, embeds)
+			itemMap = s.expandRelations(itemMap, expands)
+			resolved[i] = itemMap
 		}
-		http.Error(w, "Item not found", http.StatusNotFound)
+		page.items = resolved
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page.items)
+	return http.StatusOK
+}
+
+// handleSubResource resolves a nested route like GET /posts/1/comments to
+// the items in subCollection whose foreign key references parentCollection
+// item parentID, e.g. every comment with postId == 1.
+//
+// subCollection is authorized in its own right, the same as if it had been
+// requested directly as GET /comments: an ACL rule on the nested
+// collection can't be bypassed by reaching it through a parent route that
+// has none.
+//
+// Parameters:
+//   - w: HTTP response writer
+//   - r: HTTP request
+//   - parentCollection: Name of the parent collection (e.g. "posts")
+//   - parentID: ID of the parent item
+//   - subCollection: Name of the nested collection to filter (e.g. "comments")
+//
+// Returns:
+//   - int: HTTP response status code
+func (s *Server) handleSubResource(w http.ResponseWriter, r *http.Request, parentCollection string, parentID int, subCollection string) int {
+	if _, ok := s.authorize(w, r, subCollection); !ok {
+		return http.StatusForbidden
+	}
+
+	items, exists := s.storage.List(subCollection)
+	if !exists {
+		http.Error(w, "Collection not found", http.StatusNotFound)
 		return http.StatusNotFound
 	}
 
+	fk := foreignKey(parentCollection)
+	matched := make([]interface{}, 0)
+	for _, item := range items {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if fmt.Sprint(itemMap[fk]) == strconv.Itoa(parentID) {
+			matched = append(matched, itemMap)
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(items)
+	json.NewEncoder(w).Encode(matched)
 	return http.StatusOK
 }
 
@@ -228,16 +466,30 @@ func (s *Server) handlePost(w http.ResponseWriter, r *http.Request, collection s
 		return http.StatusBadRequest
 	}
 
-	if _, hasID := newItem["id"]; !hasID {
-		newItem["id"] = len(s.data[collection]) + 1
+	if errs, hasSchema := s.schemas.validate(collection, newItem); hasSchema && len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return http.StatusUnprocessableEntity
 	}
 
-	s.data[collection] = append(s.data[collection], newItem)
+	var id int
+	if rawID, hasID := newItem["id"]; hasID {
+		n, ok := toFloat(rawID)
+		if !ok {
+			http.Error(w, "id must be numeric", http.StatusBadRequest)
+			return http.StatusBadRequest
+		}
+		id = int(n)
+	} else {
+		items, _ := s.storage.List(collection)
+		id = len(items) + 1
+		newItem["id"] = id
+	}
 
-	if err := s.saveData(); err != nil {
+	if err := s.storage.Put(collection, id, newItem); err != nil {
 		http.Error(w, "Failed to save data", http.StatusInternalServerError)
 		return http.StatusInternalServerError
 	}
+	s.broadcastChange("create", collection, id, newItem)
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(newItem)
@@ -272,19 +524,26 @@ func (s *Server) handlePut(w http.ResponseWriter, r *http.Request, collection st
 		return http.StatusBadRequest
 	}
 
-	idx, found := s.findItemIndex(collection, id)
+	if errs, hasSchema := s.schemas.validate(collection, updatedItem); hasSchema && len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return http.StatusUnprocessableEntity
+	}
+
+	existing, found := s.storage.Get(collection, id)
 	if !found {
 		http.Error(w, "Item not found", http.StatusNotFound)
 		return http.StatusNotFound
 	}
+	if !s.authorizeOwner(w, r, collection, existing) {
+		return http.StatusForbidden
+	}
 
 	updatedItem["id"] = id
-	s.data[collection][idx] = updatedItem
-
-	if err := s.saveData(); err != nil {
+	if err := s.storage.Put(collection, id, updatedItem); err != nil {
 		http.Error(w, "Failed to save data", http.StatusInternalServerError)
 		return http.StatusInternalServerError
 	}
+	s.broadcastChange("update", collection, id, updatedItem)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(updatedItem)
@@ -313,18 +572,20 @@ func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request, collection
 		return http.StatusBadRequest
 	}
 
-	idx, found := s.findItemIndex(collection, id)
+	existing, found := s.storage.Get(collection, id)
 	if !found {
 		http.Error(w, "Item not found", http.StatusNotFound)
 		return http.StatusNotFound
 	}
+	if !s.authorizeOwner(w, r, collection, existing) {
+		return http.StatusForbidden
+	}
 
-	s.data[collection] = append(s.data[collection][:idx], s.data[collection][idx+1:]...)
-
-	if err := s.saveData(); err != nil {
+	if err := s.storage.Delete(collection, id); err != nil {
 		http.Error(w, "Failed to save data", http.StatusInternalServerError)
 		return http.StatusInternalServerError
 	}
+	s.broadcastChange("delete", collection, id, nil)
 
 	w.WriteHeader(http.StatusOK)
 	return http.StatusOK
@@ -335,9 +596,15 @@ func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request, collection
 func main() {
 	filename := flag.String("db", "db.json", "JSON database file")
 	port := flag.Int("port", 3000, "Server port")
+	storageKind := flag.String("storage", "file", "Storage backend: file, dir, memory, or bolt")
 	flag.Parse()
 
-	server, err := NewServer(*filename)
+	storage, err := newStorage(*storageKind, *filename)
+	if err != nil {
+		log.Fatalf("Error configuring storage: %v", err)
+	}
+
+	server, err := NewServer(storage)
 	if err != nil {
 		log.Fatalf("Error initializing server: %v", err)
 	}
@@ -0,0 +1,281 @@
+package gomock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChaosConfig configures the fake-latency, error-injection, and
+// rate-limiting behavior applied to a request before it reaches the normal
+// CRUD handlers, letting consumers exercise frontend/client resilience
+// against a mock backend.
+type ChaosConfig struct {
+	// DelayMin and DelayMax bound the artificial latency added before a
+	// request is handled. A fixed delay has DelayMin == DelayMax.
+	DelayMin time.Duration
+	DelayMax time.Duration
+
+	// ErrorRate is the fraction (0-1) of requests that short-circuit with
+	// ErrorStatus instead of reaching the handler.
+	ErrorRate float64
+
+	// ErrorStatus is the HTTP status returned for an injected error.
+	// Defaults to 500 when ErrorRate is set but ErrorStatus is zero.
+	ErrorStatus int
+
+	// RateLimit is the maximum number of requests allowed per client IP
+	// within RateLimitWindow. A RateLimit of 0 disables rate limiting.
+	RateLimit       int
+	RateLimitWindow time.Duration
+}
+
+// isZero reports whether cfg has no chaos behavior configured at all,
+// letting callers skip straight through.
+func (cfg ChaosConfig) isZero() bool {
+	return cfg.DelayMax == 0 && cfg.ErrorRate == 0 && cfg.RateLimit == 0
+}
+
+// chaosOverrideSpec is the JSON shape used both for the -chaos overrides
+// file and for per-route overrides embedded in a routes config entry.
+type chaosOverrideSpec struct {
+	Delay       string  `json:"delay,omitempty"`
+	ErrorRate   float64 `json:"errorRate,omitempty"`
+	ErrorStatus int     `json:"errorStatus,omitempty"`
+	RateLimit   string  `json:"rateLimit,omitempty"`
+}
+
+// resolve converts a chaosOverrideSpec into a ChaosConfig, parsing its
+// Delay and RateLimit strings.
+func (spec chaosOverrideSpec) resolve() (ChaosConfig, error) {
+	cfg := ChaosConfig{ErrorRate: spec.ErrorRate, ErrorStatus: spec.ErrorStatus}
+
+	min, max, err := parseDelayFlag(spec.Delay)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.DelayMin, cfg.DelayMax = min, max
+
+	limit, window, err := parseRateLimitFlag(spec.RateLimit)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.RateLimit, cfg.RateLimitWindow = limit, window
+
+	return cfg, nil
+}
+
+// loadChaosOverrides reads a JSON file mapping collection name to a
+// chaosOverrideSpec, as configured via the -chaos flag.
+//
+// Parameters:
+//   - filename: Path to the JSON chaos overrides file
+//
+// Returns:
+//   - map[string]ChaosConfig: Resolved per-collection overrides
+//   - error: Any error encountered while reading or parsing the file
+func loadChaosOverrides(filename string) (map[string]ChaosConfig, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var specs map[string]chaosOverrideSpec
+	if err := json.Unmarshal(raw, &specs); err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]ChaosConfig, len(specs))
+	for collection, spec := range specs {
+		cfg, err := spec.resolve()
+		if err != nil {
+			return nil, fmt.Errorf("chaos override for %q: %v", collection, err)
+		}
+		overrides[collection] = cfg
+	}
+	return overrides, nil
+}
+
+// parseDelayFlag parses a -delay flag value, either a fixed duration
+// ("200ms") or a random range ("100ms-800ms"), returning its bounds. An
+// empty value returns a zero duration on both bounds.
+func parseDelayFlag(value string) (min, max time.Duration, err error) {
+	if value == "" {
+		return 0, 0, nil
+	}
+
+	if idx := strings.Index(value, "-"); idx > 0 {
+		min, err = time.ParseDuration(value[:idx])
+		if err != nil {
+			return 0, 0, err
+		}
+		max, err = time.ParseDuration(value[idx+1:])
+		if err != nil {
+			return 0, 0, err
+		}
+		return min, max, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, 0, err
+	}
+	return d, d, nil
+}
+
+// parseRateLimitFlag parses a -rate-limit flag value shaped like
+// "100/min" or "10/s" into a request count and window. An empty value
+// disables rate limiting (count 0).
+func parseRateLimitFlag(value string) (count int, window time.Duration, err error) {
+	if value == "" {
+		return 0, 0, nil
+	}
+
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf(`invalid rate limit %q, want e.g. "100/min"`, value)
+	}
+
+	count, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch parts[1] {
+	case "s", "sec", "second":
+		window = time.Second
+	case "min", "minute":
+		window = time.Minute
+	case "h", "hour":
+		window = time.Hour
+	default:
+		return 0, 0, fmt.Errorf("invalid rate limit unit %q", parts[1])
+	}
+
+	return count, window, nil
+}
+
+// rateLimiter tracks request timestamps per client IP within a sliding
+// window, used to enforce ChaosConfig.RateLimit.
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	clients map[string][]time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, clients: make(map[string][]time.Time)}
+}
+
+// allow reports whether a new request from clientIP at time now is within
+// the rate limit, recording it if so.
+func (rl *rateLimiter) allow(clientIP string, now time.Time) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	cutoff := now.Add(-rl.window)
+	kept := rl.clients[clientIP][:0]
+	for _, t := range rl.clients[clientIP] {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+
+	if len(kept) >= rl.limit {
+		rl.clients[clientIP] = kept
+		return false
+	}
+
+	rl.clients[clientIP] = append(kept, now)
+	return true
+}
+
+// chaosForCollection returns the ChaosConfig that should apply to
+// collection: its per-collection override if one is configured, otherwise
+// the server's global chaos config.
+func (s *Server) chaosForCollection(collection string) ChaosConfig {
+	if cfg, ok := s.chaosOverrides[collection]; ok {
+		return cfg
+	}
+	return s.chaos
+}
+
+// limiterFor returns (creating if necessary) the rate limiter for cfg,
+// keyed by key so each per-collection or per-route override gets an
+// independent limit.
+func (s *Server) limiterFor(key string, cfg ChaosConfig) *rateLimiter {
+	s.limitersMu.Lock()
+	defer s.limitersMu.Unlock()
+
+	if limiter, ok := s.limiters[key]; ok {
+		return limiter
+	}
+	limiter := newRateLimiter(cfg.RateLimit, cfg.RateLimitWindow)
+	s.limiters[key] = limiter
+	return limiter
+}
+
+// applyChaos injects configured latency, errors, and rate limiting for a
+// request against collection, using its per-collection override (if any)
+// or the server's global chaos config. It returns true if the request was
+// fully handled (rate limited or failed) and the caller should stop
+// processing.
+func (s *Server) applyChaos(w http.ResponseWriter, r *http.Request, collection string) bool {
+	return s.applyChaosConfig(w, r, s.chaosForCollection(collection), collection)
+}
+
+// applyChaosConfig injects cfg's configured latency, errors, and rate
+// limiting, keying its rate limiter by limiterKey so independent callers
+// (collections, route rules) don't share a limit. It returns true if the
+// request was fully handled (rate limited or failed) and the caller should
+// stop processing.
+func (s *Server) applyChaosConfig(w http.ResponseWriter, r *http.Request, cfg ChaosConfig, limiterKey string) bool {
+	if cfg.isZero() {
+		return false
+	}
+
+	if cfg.RateLimit > 0 {
+		limiter := s.limiterFor(limiterKey, cfg)
+		if !limiter.allow(clientIP(r), time.Now()) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(cfg.RateLimitWindow.Seconds())))
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return true
+		}
+	}
+
+	if cfg.DelayMax > 0 {
+		delay := cfg.DelayMin
+		if cfg.DelayMax > cfg.DelayMin {
+			delay += time.Duration(rand.Int63n(int64(cfg.DelayMax - cfg.DelayMin)))
+		}
+		time.Sleep(delay)
+	}
+
+	if cfg.ErrorRate > 0 && rand.Float64() < cfg.ErrorRate {
+		status := cfg.ErrorStatus
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		http.Error(w, "Injected error", status)
+		return true
+	}
+
+	return false
+}
+
+// clientIP extracts the request's client IP, stripping any port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,322 @@
+package gomock
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// reservedQueryParams are query parameters that control filtering, sorting,
+// pagination, and search rather than naming a field to filter on.
+var reservedQueryParams = map[string]bool{
+	"_sort":   true,
+	"_order":  true,
+	"_start":  true,
+	"_end":    true,
+	"_limit":  true,
+	"q":       true,
+	"_embed":  true,
+	"_expand": true,
+}
+
+// fieldOperatorSuffixes are the json-server style operator suffixes
+// recognized on filter parameter names, e.g. "price_gte".
+var fieldOperatorSuffixes = []string{"_gte", "_lte", "_ne", "_like"}
+
+// applyFilters returns the subset of items matching every field-equality and
+// operator query parameter present in values. Parameters in
+// reservedQueryParams are ignored, as are path/route-only parameters such as
+// "_embed" and "_expand" which are handled separately.
+func applyFilters(items []interface{}, values url.Values) []interface{} {
+	filtered := items
+	for key, vals := range values {
+		if reservedQueryParams[key] || len(vals) == 0 {
+			continue
+		}
+
+		field, op := splitFieldOperator(key)
+		want := vals[0]
+
+		next := make([]interface{}, 0, len(filtered))
+		for _, item := range filtered {
+			itemMap, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if matchesFilter(itemMap, field, op, want) {
+				next = append(next, item)
+			}
+		}
+		filtered = next
+	}
+	return filtered
+}
+
+// splitFieldOperator splits a query parameter name such as "age_gte" into
+// its field name ("age") and operator ("_gte"). A parameter with no
+// recognized suffix is treated as a plain equality filter.
+func splitFieldOperator(key string) (field, op string) {
+	for _, suffix := range fieldOperatorSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return strings.TrimSuffix(key, suffix), suffix
+		}
+	}
+	return key, ""
+}
+
+// matchesFilter reports whether itemMap[field] satisfies the given operator
+// against the string value want. Equality compares the string form of the
+// field's value; _gte/_lte compare numerically when both sides parse as
+// numbers; _like performs a case-insensitive substring match.
+func matchesFilter(itemMap map[string]interface{}, field, op, want string) bool {
+	actual, exists := itemMap[field]
+	if !exists {
+		return false
+	}
+
+	switch op {
+	case "_gte", "_lte":
+		actualNum, aok := toFloat(actual)
+		wantNum, wok := strconv.ParseFloat(want, 64)
+		if aok && wok == nil {
+			if op == "_gte" {
+				return actualNum >= wantNum
+			}
+			return actualNum <= wantNum
+		}
+		if op == "_gte" {
+			return fmt.Sprint(actual) >= want
+		}
+		return fmt.Sprint(actual) <= want
+	case "_ne":
+		return fmt.Sprint(actual) != want
+	case "_like":
+		return strings.Contains(strings.ToLower(fmt.Sprint(actual)), strings.ToLower(want))
+	default:
+		return fmt.Sprint(actual) == want
+	}
+}
+
+// toFloat attempts to coerce v into a float64. v is usually a value decoded
+// from JSON (float64), but plain Go ints are also accepted since items
+// created in this process (e.g. a freshly assigned id) may not have made a
+// JSON round trip yet.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// applySearch returns the subset of items whose string fields contain q,
+// case-insensitively, searching nested objects and arrays recursively.
+func applySearch(items []interface{}, q string) []interface{} {
+	if q == "" {
+		return items
+	}
+	q = strings.ToLower(q)
+
+	filtered := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if containsText(item, q) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// containsText recursively scans v's string fields for the substring q.
+func containsText(v interface{}, q string) bool {
+	switch val := v.(type) {
+	case string:
+		return strings.Contains(strings.ToLower(val), q)
+	case map[string]interface{}:
+		for _, nested := range val {
+			if containsText(nested, q) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, nested := range val {
+			if containsText(nested, q) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applySort orders items according to a comma-separated "_sort" field list
+// and a matching comma-separated "_order" list ("asc"/"desc"). When fewer
+// orders are given than sort fields, the remaining fields default to "asc".
+// The sort is stable, so multi-field sorts behave as expected.
+func applySort(items []interface{}, sortParam, orderParam string) []interface{} {
+	if sortParam == "" {
+		return items
+	}
+	fields := strings.Split(sortParam, ",")
+	orders := strings.Split(orderParam, ",")
+
+	sorted := make([]interface{}, len(items))
+	copy(sorted, items)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		left, lok := sorted[i].(map[string]interface{})
+		right, rok := sorted[j].(map[string]interface{})
+		if !lok || !rok {
+			return false
+		}
+		for idx, field := range fields {
+			order := "asc"
+			if idx < len(orders) && orders[idx] != "" {
+				order = orders[idx]
+			}
+
+			cmp := compareValues(left[field], right[field])
+			if cmp == 0 {
+				continue
+			}
+			if order == "desc" {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+	return sorted
+}
+
+// compareValues compares two decoded JSON values, preferring a numeric
+// comparison when both sides are numbers or numeric strings (see toFloat)
+// and falling back to string comparison otherwise. It returns -1, 0, or 1.
+func compareValues(a, b interface{}) int {
+	if an, aok := toFloat(a); aok {
+		if bn, bok := toFloat(b); bok {
+			switch {
+			case an < bn:
+				return -1
+			case an > bn:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+	as, bs := fmt.Sprint(a), fmt.Sprint(b)
+	return strings.Compare(as, bs)
+}
+
+// paginationResult holds the page of items to return along with the total
+// count before pagination, used to populate X-Total-Count and the Link
+// header.
+type paginationResult struct {
+	items []interface{}
+	start int
+	end   int
+	total int
+}
+
+// applyPagination slices items according to "_start"/"_end"/"_limit" query
+// parameters. "_limit" alone returns the first N items; "_start" combined
+// with either "_end" or "_limit" returns a half-open range. With no
+// pagination parameters, all items are returned.
+func applyPagination(items []interface{}, values url.Values) paginationResult {
+	total := len(items)
+	result := paginationResult{items: items, start: 0, end: total, total: total}
+
+	limit, hasLimit := parseIntParam(values, "_limit")
+	start, hasStart := parseIntParam(values, "_start")
+	end, hasEnd := parseIntParam(values, "_end")
+
+	if !hasStart && !hasEnd && !hasLimit {
+		return result
+	}
+
+	if hasStart {
+		result.start = start
+	}
+	switch {
+	case hasEnd:
+		result.end = end
+	case hasLimit:
+		result.end = result.start + limit
+	}
+
+	if result.start < 0 {
+		result.start = 0
+	}
+	if result.end > total {
+		result.end = total
+	}
+	if result.start > result.end {
+		result.start = result.end
+	}
+
+	result.items = items[result.start:result.end]
+	return result
+}
+
+// parseIntParam reads a single integer query parameter, returning ok=false
+// if it is absent or not a valid integer.
+func parseIntParam(values url.Values, key string) (int, bool) {
+	raw := values.Get(key)
+	if raw == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// writePaginationHeaders sets X-Total-Count and an RFC 5988 Link header
+// describing first/prev/next/last pages relative to the current _start/_end
+// (or _limit) window.
+func writePaginationHeaders(w http.ResponseWriter, r *http.Request, p paginationResult) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(p.total))
+
+	pageSize := p.end - p.start
+	if pageSize <= 0 || p.total == 0 {
+		return
+	}
+
+	base := *r.URL
+	links := make([]string, 0, 4)
+	addLink := func(rel string, start, end int) {
+		q := base.Query()
+		q.Set("_start", strconv.Itoa(start))
+		q.Set("_end", strconv.Itoa(end))
+		base.RawQuery = q.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, base.String(), rel))
+	}
+
+	addLink("first", 0, pageSize)
+	if p.start > 0 {
+		prevStart := p.start - pageSize
+		if prevStart < 0 {
+			prevStart = 0
+		}
+		addLink("prev", prevStart, prevStart+pageSize)
+	}
+	if p.end < p.total {
+		addLink("next", p.end, p.end+pageSize)
+	}
+	lastStart := p.total - pageSize
+	if lastStart < 0 {
+		lastStart = 0
+	}
+	addLink("last", lastStart, p.total)
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,176 @@
+package gomock
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// singularize applies a small set of English pluralization rules to derive
+// the singular form of a collection name, e.g. "posts" -> "post",
+// "categories" -> "category". It is intentionally simple: it only needs to
+// handle the collection names that appear in typical mock databases, not
+// general English.
+func singularize(collection string) string {
+	switch {
+	case strings.HasSuffix(collection, "ies"):
+		return strings.TrimSuffix(collection, "ies") + "y"
+	case strings.HasSuffix(collection, "ses"):
+		return strings.TrimSuffix(collection, "es")
+	case strings.HasSuffix(collection, "s"):
+		return strings.TrimSuffix(collection, "s")
+	default:
+		return collection
+	}
+}
+
+// foreignKey returns the conventional foreign-key field name a child
+// collection item uses to reference an item in parentCollection, e.g.
+// "posts" -> "postId".
+func foreignKey(parentCollection string) string {
+	singular := singularize(parentCollection)
+	return singular + "Id"
+}
+
+// authorizeRelations runs every "_embed"/"_expand" target collection an
+// item's embeds/expands would read from through the same ACL check as a
+// direct request against that collection, so an ACL rule on e.g.
+// "comments" can't be bypassed by requesting it as ?_embed=comments on an
+// unprotected parent collection.
+//
+// Returns:
+//   - bool: whether every target collection is authorized
+func (s *Server) authorizeRelations(w http.ResponseWriter, r *http.Request, embeds, expands []string) bool {
+	for _, embedCollection := range embeds {
+		embedCollection = strings.TrimSpace(embedCollection)
+		if embedCollection == "" {
+			continue
+		}
+		if _, ok := s.authorize(w, r, embedCollection); !ok {
+			return false
+		}
+	}
+
+	for _, expand := range expands {
+		expand = strings.TrimSpace(expand)
+		if expand == "" {
+			continue
+		}
+		if _, ok := s.authorize(w, r, pluralize(expand)); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// embedRelations resolves "_embed" query parameters on an item in
+// parentCollection. For each embed name (itself a collection name), it
+// finds every item in that collection whose foreign key matches the
+// parent's id and attaches them under that collection name.
+//
+// item is never mutated: storage.Get/List hand back the live maps held by
+// the storage backend, so embedRelations returns a shallow copy with the
+// embedded data added rather than writing into item directly, which would
+// otherwise leak into subsequent reads and get persisted on the next save.
+func (s *Server) embedRelations(item map[string]interface{}, parentCollection string, embeds []string) map[string]interface{} {
+	id, ok := item["id"]
+	if !ok {
+		return item
+	}
+
+	result := copyItem(item)
+	for _, embedCollection := range embeds {
+		embedCollection = strings.TrimSpace(embedCollection)
+		if embedCollection == "" {
+			continue
+		}
+
+		fk := foreignKey(parentCollection)
+		children := make([]interface{}, 0)
+		childItems, _ := s.storage.List(embedCollection)
+		for _, child := range childItems {
+			childMap, ok := child.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if fmt.Sprint(childMap[fk]) == fmt.Sprint(id) {
+				children = append(children, childMap)
+			}
+		}
+		result[embedCollection] = children
+	}
+	return result
+}
+
+// expandRelations resolves "_expand" query parameters on an item. For each
+// expand name (a singular resource name, e.g. "user"), it looks up the
+// referenced item in the pluralized collection using the "<name>Id"
+// foreign key already present on item, and attaches the full referenced
+// item under that name.
+//
+// Like embedRelations, it returns a shallow copy rather than mutating item
+// in place, so resolving relations on a GET never contaminates the stored
+// item.
+func (s *Server) expandRelations(item map[string]interface{}, expands []string) map[string]interface{} {
+	result := copyItem(item)
+	for _, expand := range expands {
+		expand = strings.TrimSpace(expand)
+		if expand == "" {
+			continue
+		}
+
+		fk := expand + "Id"
+		refID, ok := item[fk]
+		if !ok {
+			continue
+		}
+
+		collection := pluralize(expand)
+		candidates, _ := s.storage.List(collection)
+		for _, candidate := range candidates {
+			candidateMap, ok := candidate.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if fmt.Sprint(candidateMap["id"]) == fmt.Sprint(refID) {
+				result[expand] = candidateMap
+				break
+			}
+		}
+	}
+	return result
+}
+
+// copyItem returns a shallow copy of item, used to avoid mutating maps
+// handed back by the storage layer.
+func copyItem(item map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(item)+2)
+	for k, v := range item {
+		result[k] = v
+	}
+	return result
+}
+
+// pluralize is the loose inverse of singularize, used to derive a
+// collection name from a singular relation name like "user" -> "users" or
+// "category" -> "categories".
+func pluralize(name string) string {
+	switch {
+	case strings.HasSuffix(name, "y"):
+		return strings.TrimSuffix(name, "y") + "ies"
+	case strings.HasSuffix(name, "s"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
+
+// splitCSV splits a comma-separated query parameter value into its parts,
+// returning nil for an empty string so callers can range over it safely.
+func splitCSV(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
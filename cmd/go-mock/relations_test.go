@@ -0,0 +1,68 @@
+package gomock
+
+import "testing"
+
+func newRelationsTestServer(t *testing.T) *Server {
+	t.Helper()
+	server, err := NewServer(NewMemoryStorage())
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	return server
+}
+
+// TestEmbedRelationsDoesNotMutateStoredItem guards against the item map
+// returned by storage.Get/List being the live map held by the storage
+// backend: embedding must not leave an "_embed"ed collection key behind on
+// the stored item for subsequent plain reads.
+func TestEmbedRelationsDoesNotMutateStoredItem(t *testing.T) {
+	server := newRelationsTestServer(t)
+
+	post := map[string]interface{}{"id": 1, "title": "hello"}
+	if err := server.storage.Put("posts", 1, post); err != nil {
+		t.Fatalf("Put posts: %v", err)
+	}
+	comment := map[string]interface{}{"id": 1, "postId": 1, "body": "nice post"}
+	if err := server.storage.Put("comments", 1, comment); err != nil {
+		t.Fatalf("Put comments: %v", err)
+	}
+
+	stored, _ := server.storage.Get("posts", 1)
+	embedded := server.embedRelations(stored, "posts", []string{"comments"})
+
+	if _, ok := embedded["comments"]; !ok {
+		t.Fatalf("expected embedded result to carry \"comments\", got %#v", embedded)
+	}
+
+	again, _ := server.storage.Get("posts", 1)
+	if _, ok := again["comments"]; ok {
+		t.Fatalf("embedRelations mutated the stored item: %#v", again)
+	}
+}
+
+// TestExpandRelationsDoesNotMutateStoredItem mirrors
+// TestEmbedRelationsDoesNotMutateStoredItem for "_expand".
+func TestExpandRelationsDoesNotMutateStoredItem(t *testing.T) {
+	server := newRelationsTestServer(t)
+
+	user := map[string]interface{}{"id": 1, "name": "ada"}
+	if err := server.storage.Put("users", 1, user); err != nil {
+		t.Fatalf("Put users: %v", err)
+	}
+	post := map[string]interface{}{"id": 1, "userId": 1, "title": "hello"}
+	if err := server.storage.Put("posts", 1, post); err != nil {
+		t.Fatalf("Put posts: %v", err)
+	}
+
+	stored, _ := server.storage.Get("posts", 1)
+	expanded := server.expandRelations(stored, []string{"user"})
+
+	if _, ok := expanded["user"]; !ok {
+		t.Fatalf("expected expanded result to carry \"user\", got %#v", expanded)
+	}
+
+	again, _ := server.storage.Get("posts", 1)
+	if _, ok := again["user"]; ok {
+		t.Fatalf("expandRelations mutated the stored item: %#v", again)
+	}
+}
@@ -0,0 +1,242 @@
+package gomock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// RouteConfig holds the set of user-defined route rules loaded from a
+// routes file (via the -routes flag), matched against incoming requests
+// before falling back to the default collection CRUD handler.
+type RouteConfig struct {
+	Rules []RouteRule
+}
+
+// RouteRule describes a single entry in the routes file. A rule either
+// rewrites the request to a different collection path (Target) or returns a
+// canned response built from Body, Status, and Headers. Path may contain
+// ":param" placeholders and a trailing "*" wildcard, e.g.
+// "/api/posts/:id" or "/api/files/*".
+type RouteRule struct {
+	// Path is the URL pattern this rule matches, e.g. "/api/posts/:id".
+	Path string `json:"path"`
+
+	// Target, if set, rewrites the matched request to this collection path
+	// (itself allowed to contain ":param" placeholders) and lets the
+	// default CRUD handler serve it.
+	Target string `json:"target,omitempty"`
+
+	// Status is the HTTP status code for a canned response. Defaults to 200.
+	Status int `json:"status,omitempty"`
+
+	// Headers are extra response headers set on a canned response.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Delay, if set, is a Go duration string (e.g. "200ms") to wait before
+	// the rule is applied, whether it writes a canned response or rewrites
+	// the request.
+	Delay string `json:"delay,omitempty"`
+
+	// Body is a text/template string for a canned response, interpolated
+	// with ".params", ".query", and ".body" (the decoded JSON request body,
+	// when present). If Body is empty and Target is set, no canned response
+	// is written; the request is rewritten and passed through instead.
+	Body string `json:"body,omitempty"`
+
+	// Chaos overrides the global -delay/-error-rate/-rate-limit behavior
+	// for requests matching this rule, the same way a -chaos entry does
+	// for a collection. It applies to every request this rule matches,
+	// including canned responses, not just ones rewritten to a collection.
+	Chaos *chaosOverrideSpec `json:"chaos,omitempty"`
+
+	pattern     *regexp.Regexp
+	params      []string
+	chaosConfig ChaosConfig
+}
+
+// loadRouteConfig reads and compiles the routes file at filename.
+//
+// Parameters:
+//   - filename: Path to the JSON routes file
+//
+// Returns:
+//   - *RouteConfig: Compiled route rules
+//   - error: Any error encountered while reading or compiling the file
+func loadRouteConfig(filename string) (*RouteConfig, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []RouteRule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return nil, err
+	}
+
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("route %q: %v", rules[i].Path, err)
+		}
+	}
+
+	return &RouteConfig{Rules: rules}, nil
+}
+
+// compile turns a Path like "/api/posts/:id" into a matching regexp and
+// records the names of its ":param" segments, in order.
+func (rule *RouteRule) compile() error {
+	segments := strings.Split(strings.Trim(rule.Path, "/"), "/")
+	var patternParts []string
+
+	for _, segment := range segments {
+		switch {
+		case segment == "*":
+			patternParts = append(patternParts, "(?P<wildcard>.*)")
+		case strings.HasPrefix(segment, ":"):
+			name := strings.TrimPrefix(segment, ":")
+			rule.params = append(rule.params, name)
+			patternParts = append(patternParts, fmt.Sprintf("(?P<%s>[^/]+)", name))
+		default:
+			patternParts = append(patternParts, regexp.QuoteMeta(segment))
+		}
+	}
+
+	pattern, err := regexp.Compile("^/" + strings.Join(patternParts, "/") + "$")
+	if err != nil {
+		return err
+	}
+	rule.pattern = pattern
+
+	if rule.Chaos != nil {
+		cfg, err := rule.Chaos.resolve()
+		if err != nil {
+			return fmt.Errorf("chaos override: %v", err)
+		}
+		rule.chaosConfig = cfg
+	}
+
+	return nil
+}
+
+// match reports whether path satisfies the rule, returning the extracted
+// named path parameters on success.
+func (rule *RouteRule) match(path string) (map[string]string, bool) {
+	m := rule.pattern.FindStringSubmatch(path)
+	if m == nil {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, name := range rule.pattern.SubexpNames() {
+		if i == 0 || name == "" {
+			continue
+		}
+		params[name] = m[i]
+	}
+	return params, true
+}
+
+// match finds the first rule matching r's URL path, returning its resolved
+// path parameters alongside it.
+func (rc *RouteConfig) match(r *http.Request) (*RouteRule, map[string]string) {
+	if rc == nil {
+		return nil, nil
+	}
+	path := r.URL.Path
+	for i := range rc.Rules {
+		if params, ok := rc.Rules[i].match(path); ok {
+			return &rc.Rules[i], params
+		}
+	}
+	return nil, nil
+}
+
+// serve applies rule to the request: if the rule defines a Body template,
+// it renders and writes a canned response (after any configured Delay).
+// Otherwise it rewrites r.URL.Path to rule's Target (substituting path
+// parameters) and returns false so the caller falls through to the default
+// CRUD handler.
+//
+// Returns:
+//   - bool: true if the rule fully handled the response, false if the
+//     request was rewritten and should continue to the default handler
+func (rule *RouteRule) serve(w http.ResponseWriter, r *http.Request, params map[string]string) bool {
+	if rule.Delay != "" {
+		if d, err := time.ParseDuration(rule.Delay); err == nil {
+			time.Sleep(d)
+		}
+	}
+
+	if rule.Body == "" {
+		if rule.Target != "" {
+			r.URL.Path = substituteParams(rule.Target, params)
+		}
+		return false
+	}
+
+	var body map[string]interface{}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	data := map[string]interface{}{
+		"params": params,
+		"query":  flattenQuery(r.URL.Query()),
+		"body":   body,
+	}
+
+	tmpl, err := template.New("route").Parse(rule.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid route template: %v", err), http.StatusInternalServerError)
+		return true
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		http.Error(w, fmt.Sprintf("route template error: %v", err), http.StatusInternalServerError)
+		return true
+	}
+
+	for key, value := range rule.Headers {
+		w.Header().Set(key, value)
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	status := rule.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+	return true
+}
+
+// substituteParams replaces ":name" placeholders in target with their
+// matched values from params.
+func substituteParams(target string, params map[string]string) string {
+	for name, value := range params {
+		target = strings.ReplaceAll(target, ":"+name, value)
+	}
+	return target
+}
+
+// flattenQuery reduces a url.Values into a map of single string values,
+// taking the first value for any repeated parameter, for use in templates.
+func flattenQuery(values map[string][]string) map[string]string {
+	flat := make(map[string]string, len(values))
+	for key, vals := range values {
+		if len(vals) > 0 {
+			flat[key] = vals[0]
+		}
+	}
+	return flat
+}
@@ -0,0 +1,40 @@
+package gomock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRouteRuleChaosRateLimit guards against a route rule's "chaos"
+// override being ignored: a rule with a 1/min rate limit must reject the
+// second request that hits it within the window, even though the rule
+// fully handles the response itself and never reaches applyChaos via a
+// collection.
+func TestRouteRuleChaosRateLimit(t *testing.T) {
+	server := newRelationsTestServer(t)
+
+	rule := RouteRule{
+		Path:  "/api/ping",
+		Body:  `{"ok": true}`,
+		Chaos: &chaosOverrideSpec{RateLimit: "1/min"},
+	}
+	if err := rule.compile(); err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	server.routes = &RouteConfig{Rules: []RouteRule{rule}}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	rec := httptest.NewRecorder()
+	server.handleCollection(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first request: expected %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	rec = httptest.NewRecorder()
+	server.handleCollection(rec, req)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: expected %d, got %d", http.StatusTooManyRequests, rec.Code)
+	}
+}
@@ -0,0 +1,122 @@
+package gomock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// schemaRegistry holds a compiled JSON Schema (draft 2020-12) per
+// collection, loaded from the -schemas file.
+type schemaRegistry struct {
+	schemas map[string]*jsonschema.Schema
+}
+
+// validationError is a single JSON Schema validation failure, reported in
+// the 422 response body.
+type validationError struct {
+	Path    string `json:"path"`
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+// loadSchemaRegistry reads the -schemas file, a JSON object mapping
+// collection name to its JSON Schema (draft 2020-12), and compiles each
+// entry.
+//
+// Parameters:
+//   - filename: Path to the JSON schemas file
+//
+// Returns:
+//   - *schemaRegistry: Compiled per-collection schemas
+//   - error: Any error encountered while reading or compiling a schema
+func loadSchemaRegistry(filename string) (*schemaRegistry, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawSchemas map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &rawSchemas); err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+
+	schemas := make(map[string]*jsonschema.Schema, len(rawSchemas))
+	for collection, rawSchema := range rawSchemas {
+		url := "mem://schemas/" + collection + ".json"
+		if err := compiler.AddResource(url, bytes.NewReader(rawSchema)); err != nil {
+			return nil, fmt.Errorf("schema for %q: %v", collection, err)
+		}
+		schema, err := compiler.Compile(url)
+		if err != nil {
+			return nil, fmt.Errorf("schema for %q: %v", collection, err)
+		}
+		schemas[collection] = schema
+	}
+
+	return &schemaRegistry{schemas: schemas}, nil
+}
+
+// validate checks body against the schema registered for collection. The
+// second return value reports whether a schema was registered at all; when
+// it is false, callers should treat the collection as unvalidated rather
+// than as passing validation.
+func (sr *schemaRegistry) validate(collection string, body map[string]interface{}) ([]validationError, bool) {
+	if sr == nil {
+		return nil, false
+	}
+
+	schema, ok := sr.schemas[collection]
+	if !ok {
+		return nil, false
+	}
+
+	if err := schema.Validate(body); err != nil {
+		if ve, ok := err.(*jsonschema.ValidationError); ok {
+			return flattenValidationErrors(ve), true
+		}
+		return []validationError{{Message: err.Error()}}, true
+	}
+	return nil, true
+}
+
+// flattenValidationErrors walks a jsonschema.ValidationError's cause tree
+// down to its leaves, which carry the actual failing keyword and location.
+func flattenValidationErrors(ve *jsonschema.ValidationError) []validationError {
+	if len(ve.Causes) == 0 {
+		return []validationError{{
+			Path:    ve.InstanceLocation,
+			Keyword: lastSegment(ve.KeywordLocation),
+			Message: ve.Message,
+		}}
+	}
+
+	var errs []validationError
+	for _, cause := range ve.Causes {
+		errs = append(errs, flattenValidationErrors(cause)...)
+	}
+	return errs
+}
+
+// lastSegment returns the final "/"-separated segment of a JSON Schema
+// keyword location, e.g. "/properties/age/minimum" -> "minimum".
+func lastSegment(location string) string {
+	parts := strings.Split(location, "/")
+	return parts[len(parts)-1]
+}
+
+// writeValidationErrors writes a 422 Unprocessable Entity response body
+// listing every validation failure.
+func writeValidationErrors(w http.ResponseWriter, errs []validationError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]interface{}{"errors": errs})
+}
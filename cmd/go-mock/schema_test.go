@@ -0,0 +1,78 @@
+package gomock
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func newTestSchemaRegistry(t *testing.T) *schemaRegistry {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schemas.json")
+	contents := `{
+		"users": {
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"age": {"type": "integer", "minimum": 0}
+			},
+			"required": ["name"]
+		}
+	}`
+	if err := ioutil.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+
+	registry, err := loadSchemaRegistry(path)
+	if err != nil {
+		t.Fatalf("loadSchemaRegistry: %v", err)
+	}
+	return registry
+}
+
+// TestSchemaRegistryValidatePasses guards against validate's call into the
+// jsonschema package regressing to a method that doesn't exist on
+// *jsonschema.Schema; a body satisfying the schema must validate cleanly.
+func TestSchemaRegistryValidatePasses(t *testing.T) {
+	registry := newTestSchemaRegistry(t)
+
+	errs, hasSchema := registry.validate("users", map[string]interface{}{"name": "ada", "age": float64(30)})
+	if !hasSchema {
+		t.Fatalf("expected a schema to be registered for \"users\"")
+	}
+	if len(errs) != 0 {
+		t.Fatalf("expected no validation errors, got %#v", errs)
+	}
+}
+
+// TestSchemaRegistryValidateFails checks that a body violating the schema
+// is reported as one or more validationErrors rather than a bare error or
+// panic.
+func TestSchemaRegistryValidateFails(t *testing.T) {
+	registry := newTestSchemaRegistry(t)
+
+	errs, hasSchema := registry.validate("users", map[string]interface{}{"age": float64(-1)})
+	if !hasSchema {
+		t.Fatalf("expected a schema to be registered for \"users\"")
+	}
+	if len(errs) == 0 {
+		t.Fatalf("expected validation errors for a missing required field and a negative age")
+	}
+}
+
+// TestSchemaRegistryValidateUnknownCollection checks that a collection
+// with no registered schema is reported as unvalidated rather than as
+// passing validation.
+func TestSchemaRegistryValidateUnknownCollection(t *testing.T) {
+	registry := newTestSchemaRegistry(t)
+
+	errs, hasSchema := registry.validate("posts", map[string]interface{}{"title": "hello"})
+	if hasSchema {
+		t.Fatalf("expected no schema registered for \"posts\"")
+	}
+	if errs != nil {
+		t.Fatalf("expected nil errs for an unvalidated collection, got %#v", errs)
+	}
+}
@@ -0,0 +1,295 @@
+package gomock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Storage abstracts the persistence layer used by Server, so the backing
+// store can be swapped between a single JSON file, a directory of
+// per-collection files, an embedded KV store, or a purely in-memory store
+// for ephemeral test runs, all selected via the -storage flag.
+type Storage interface {
+	// Load populates the in-memory collections from the backing store.
+	Load() error
+
+	// Save persists the full in-memory state. Backends that persist on
+	// every mutation (DirStorage, BoltStorage) may implement this as a
+	// no-op.
+	Save() error
+
+	// Get returns the item with id in collection, and whether it was found.
+	Get(collection string, id int) (map[string]interface{}, bool)
+
+	// Put inserts or updates the item with id in collection, persisting
+	// the change according to the backend's own strategy.
+	Put(collection string, id int, item map[string]interface{}) error
+
+	// Delete removes the item with id from collection, persisting the
+	// change according to the backend's own strategy.
+	Delete(collection string, id int) error
+
+	// List returns every item in collection, and whether the collection
+	// exists.
+	List(collection string) ([]interface{}, bool)
+}
+
+// memStore is the in-memory collection map shared by every Storage
+// implementation; backends differ only in how, and when, they persist it.
+type memStore struct {
+	mu   sync.RWMutex
+	data map[string][]interface{}
+}
+
+func newMemStore() memStore {
+	return memStore{data: make(map[string][]interface{})}
+}
+
+// List returns a copy of the collection's item slice header; callers must
+// not rely on mutating the returned slice to affect storage.
+func (m *memStore) List(collection string) ([]interface{}, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items, exists := m.data[collection]
+	return items, exists
+}
+
+// Get finds the item with the given id by scanning the collection.
+func (m *memStore) Get(collection string, id int) (map[string]interface{}, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, item := range m.data[collection] {
+		if itemMap, ok := item.(map[string]interface{}); ok && idEquals(itemMap["id"], id) {
+			return itemMap, true
+		}
+	}
+	return nil, false
+}
+
+// put inserts item under id, replacing any existing item with that id.
+func (m *memStore) put(collection string, id int, item map[string]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, existing := range m.data[collection] {
+		if existingMap, ok := existing.(map[string]interface{}); ok && idEquals(existingMap["id"], id) {
+			m.data[collection][i] = item
+			return
+		}
+	}
+	m.data[collection] = append(m.data[collection], item)
+}
+
+// delete removes the item with id from collection, reporting whether it
+// was present.
+func (m *memStore) delete(collection string, id int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := m.data[collection]
+	for i, item := range items {
+		if itemMap, ok := item.(map[string]interface{}); ok && idEquals(itemMap["id"], id) {
+			m.data[collection] = append(items[:i], items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// idEquals reports whether v, an item's decoded "id" field, represents the
+// same value as id. Items loaded from JSON hold numeric ids as float64;
+// items created in this process before their first reload may still hold a
+// plain int, so both are compared numerically.
+func idEquals(v interface{}, id int) bool {
+	n, ok := toFloat(v)
+	return ok && int(n) == id
+}
+
+// FileStorage is the default Storage backend: every collection lives in a
+// single JSON file, loaded entirely into memory and rewritten in full on
+// every mutation.
+type FileStorage struct {
+	memStore
+	filename string
+}
+
+// NewFileStorage returns a FileStorage backed by filename.
+func NewFileStorage(filename string) *FileStorage {
+	return &FileStorage{memStore: newMemStore(), filename: filename}
+}
+
+// Load reads and unmarshals the entire JSON database file.
+func (fs *FileStorage) Load() error {
+	file, err := os.Open(fs.filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	byteValue, err := ioutil.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return json.Unmarshal(byteValue, &fs.data)
+}
+
+// Save writes the current in-memory data to the JSON file.
+func (fs *FileStorage) Save() error {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	raw, err := json.MarshalIndent(fs.data, "", " ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fs.filename, raw, 0644)
+}
+
+func (fs *FileStorage) Put(collection string, id int, item map[string]interface{}) error {
+	fs.put(collection, id, item)
+	return fs.Save()
+}
+
+func (fs *FileStorage) Delete(collection string, id int) error {
+	fs.delete(collection, id)
+	return fs.Save()
+}
+
+// MemoryStorage keeps collections purely in memory and never touches disk,
+// useful for ephemeral test runs where persistence across restarts is
+// unwanted.
+type MemoryStorage struct {
+	memStore
+}
+
+// NewMemoryStorage returns an empty, disk-free MemoryStorage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{memStore: newMemStore()}
+}
+
+func (ms *MemoryStorage) Load() error { return nil }
+func (ms *MemoryStorage) Save() error { return nil }
+
+func (ms *MemoryStorage) Put(collection string, id int, item map[string]interface{}) error {
+	ms.put(collection, id, item)
+	return nil
+}
+
+func (ms *MemoryStorage) Delete(collection string, id int) error {
+	ms.delete(collection, id)
+	return nil
+}
+
+// DirStorage shards each collection into its own JSON file under a
+// directory, so a mutation only rewrites the single affected collection's
+// file instead of the entire database.
+type DirStorage struct {
+	memStore
+	dir string
+}
+
+// NewDirStorage returns a DirStorage rooted at dir.
+func NewDirStorage(dir string) *DirStorage {
+	return &DirStorage{memStore: newMemStore(), dir: dir}
+}
+
+// Load reads every "<collection>.json" file in the storage directory.
+func (ds *DirStorage) Load() error {
+	if err := os.MkdirAll(ds.dir, 0755); err != nil {
+		return err
+	}
+
+	entries, err := ioutil.ReadDir(ds.dir)
+	if err != nil {
+		return err
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		raw, err := ioutil.ReadFile(filepath.Join(ds.dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		var items []interface{}
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+		collection := strings.TrimSuffix(entry.Name(), ".json")
+		ds.data[collection] = items
+	}
+	return nil
+}
+
+// Save rewrites every collection's file. Put and Delete normally persist
+// only the collection they touched, so Save is mainly useful for an
+// initial flush of a collection created entirely in memory.
+func (ds *DirStorage) Save() error {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	for collection := range ds.data {
+		if err := ds.saveCollectionLocked(collection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveCollectionLocked rewrites only the file backing collection. Callers
+// must hold ds.mu.
+func (ds *DirStorage) saveCollectionLocked(collection string) error {
+	raw, err := json.MarshalIndent(ds.data[collection], "", " ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(ds.dir, collection+".json"), raw, 0644)
+}
+
+func (ds *DirStorage) Put(collection string, id int, item map[string]interface{}) error {
+	ds.put(collection, id, item)
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.saveCollectionLocked(collection)
+}
+
+func (ds *DirStorage) Delete(collection string, id int) error {
+	ds.delete(collection, id)
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.saveCollectionLocked(collection)
+}
+
+// newStorage constructs the Storage backend named by kind ("file", "dir",
+// "memory", or "bolt"), using path as the database file or directory.
+//
+// Parameters:
+//   - kind: Storage backend name
+//   - path: Database file or directory path, as appropriate for kind
+//
+// Returns:
+//   - Storage: Constructed (but not yet loaded) storage backend
+//   - error: If kind is unrecognized or the backend fails to initialize
+func newStorage(kind, path string) (Storage, error) {
+	switch kind {
+	case "", "file":
+		return NewFileStorage(path), nil
+	case "memory":
+		return NewMemoryStorage(), nil
+	case "dir":
+		return NewDirStorage(path), nil
+	case "bolt":
+		return NewBoltStorage(path)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", kind)
+	}
+}